@@ -3,16 +3,25 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"io/fs"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/SkinDelta/go-cs2-cdn/internal/cache"
 	"github.com/SkinDelta/go-cs2-cdn/internal/cmdrunner"
 	"github.com/SkinDelta/go-cs2-cdn/internal/dependencies"
+	"github.com/SkinDelta/go-cs2-cdn/internal/logging"
 	"github.com/SkinDelta/go-cs2-cdn/internal/parser"
+	"github.com/SkinDelta/go-cs2-cdn/internal/plugin"
+	"github.com/SkinDelta/go-cs2-cdn/internal/storage"
 )
 
 const (
@@ -20,9 +29,39 @@ const (
 	VPKDir   string = "data/game/csgo/pak01_dir.vpk"
 	ImageDir string = "panorama/images/econ"
 	BaseDir  string = "game/csgo"
+	AppID    string = "730"
+	DepotID  string = "2347770"
+
+	// archiveDownloadTimeout bounds a single DepotDownloader invocation.
+	// VPK archives run into the hundreds of megabytes, far past what
+	// cmdrunner.RunCommand's 60-second default allows, so downloadArchive
+	// builds its own longer-lived context instead of using it.
+	archiveDownloadTimeout = 30 * time.Minute
+)
+
+var (
+	storageConfigPath = flag.String("storage-config", "", "path to a YAML storage config (see internal/storage.Config); falls back to $CDN_STORAGE_URL")
+	cacheDir          = flag.String("cache-dir", "", "directory for the download cache; defaults to ~/.cache/go-cs2-cdn/downloads")
+	noCache           = flag.Bool("no-cache", false, "bypass the download cache and force re-downloads")
+	verifyCache       = flag.Bool("verify", false, "re-verify cached downloads' checksums before reuse")
+	logFormat         = flag.String("log-format", "text", "log output format: text or json")
+	logLevel          = flag.String("log-level", "info", "log level: debug, info, warn, or error")
+	logFile           = flag.String("log-file", "", "file to append log output to; defaults to stderr")
+	pluginsDir        = flag.String("plugins-dir", "plugins", "directory to discover plugin.yaml manifests under")
 )
 
 func main() {
+	flag.Parse()
+
+	closeLog, err := logging.Configure(logging.Options{Format: *logFormat, Level: *logLevel, FilePath: *logFile})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logging: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+
+	ctx := context.Background()
+
 	// ---------------------------------------
 	// 0) Check dependencies
 	// ---------------------------------------
@@ -31,37 +70,45 @@ func main() {
 	//---------------------------------------
 	// 1) Run DepotDownloader to get the manifest
 	//---------------------------------------
+	slog.InfoContext(ctx, "collecting manifest")
 	result := cmdrunner.RunCommand("tools/DepotDownloader",
-		"-app", "730",
-		"-depot", "2347770",
+		"-app", AppID,
+		"-depot", DepotID,
 		"-dir", "data",
 		"-manifest-only",
 	)
 	if result.Error != nil {
-		log.Fatalf("DepotDownloader encountered an error: %v", result.Error)
+		slog.ErrorContext(ctx, "DepotDownloader encountered an error", slog.Any("error", result.Error))
+		os.Exit(1)
 	}
-	log.Println("Collecting Manifest...")
-	log.Println("Finished collecting manifest.")
+	slog.InfoContext(ctx, "finished collecting manifest")
 
 	//---------------------------------------
 	// 2) Find the newly-downloaded manifest
 	//---------------------------------------
 	matches, err := filepath.Glob("data/manifest_*")
 	if err != nil {
-		log.Fatalf("Failed to find manifest file: %v", err)
+		slog.ErrorContext(ctx, "failed to find manifest file", slog.Any("error", err))
+		os.Exit(1)
 	}
 	if len(matches) == 0 {
-		log.Fatalf("No manifest file found in ./data/")
+		slog.ErrorContext(ctx, "no manifest file found in ./data/")
+		os.Exit(1)
 	}
 	manifestFile := matches[0]
 
 	// Example file: data/manifest_2347770_5002689339188222421.txt
 	parts := strings.Split(manifestFile, "_")
 	if len(parts) < 3 {
-		log.Fatalf("Unexpected manifest file format: %s", manifestFile)
+		slog.ErrorContext(ctx, "unexpected manifest file format", slog.String("file", manifestFile))
+		os.Exit(1)
 	}
 	newManifestID := strings.TrimSuffix(parts[2], ".txt")
 
+	// From here on, every log line carries the manifest and depot IDs for this run.
+	logger := slog.With(slog.String("manifest_id", newManifestID), slog.String("depot_id", DepotID))
+	ctx = context.Background()
+
 	//---------------------------------------
 	// 3) Read any previously stored manifest ID (if file is present)
 	//---------------------------------------
@@ -69,14 +116,15 @@ func main() {
 	if fileExists("manifest_id.txt") {
 		data, err := os.ReadFile("manifest_id.txt")
 		if err != nil {
-			log.Fatalf("Could not read manifest_id.txt: %v", err)
+			logger.ErrorContext(ctx, "could not read manifest_id.txt", slog.Any("error", err))
+			os.Exit(1)
 		}
 		trackedID = strings.TrimSpace(string(data))
 	}
 
 	// If IDs match and it's non-empty, bail out
 	if trackedID == newManifestID && trackedID != "" {
-		log.Println("Manifest ID matches the current ID. Exiting.")
+		logger.InfoContext(ctx, "manifest ID matches the current ID, exiting")
 		return
 	}
 
@@ -84,42 +132,47 @@ func main() {
 	// 4) If we get here, either the file doesn’t exist,
 	//    or it’s empty, or the ID didn’t match → download files & run extraction
 	//---------------------------------------
-	log.Printf("Manifest is new or changed. New ID: %s\n", newManifestID)
+	logger.InfoContext(ctx, "manifest is new or changed")
 
 	err = os.WriteFile("manifest_id.txt", []byte(newManifestID), 0644)
 	if err != nil {
-		log.Fatalf("Could not write to manifest_id.txt: %v", err)
+		logger.ErrorContext(ctx, "could not write to manifest_id.txt", slog.Any("error", err))
+		os.Exit(1)
 	}
-	log.Printf("New manifest ID %s has been saved.\n", newManifestID)
+	logger.InfoContext(ctx, "saved new manifest ID")
 
 	// ---------------------------------------
 	// 5) Run DepotDownloader to get the VPK dir
 	// ---------------------------------------
 	dirFile, err := os.CreateTemp("", "dir-file_*.txt")
 	if err != nil {
-		log.Fatalf("Error creating temporary file: %v", err)
+		logger.ErrorContext(ctx, "error creating temporary file", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer os.Remove(dirFile.Name())
 	defer dirFile.Close()
 
 	writer := bufio.NewWriter(dirFile)
 	if _, err := writer.WriteString("game/csgo/pak01_dir.vpk" + "\n"); err != nil {
-		log.Fatalf("Error writing to dir-file.txt: %v", err)
+		logger.ErrorContext(ctx, "error writing to dir-file.txt", slog.Any("error", err))
+		os.Exit(1)
 	}
 	if err := writer.Flush(); err != nil {
-		log.Fatalf("Error flushing to dir-file.txt: %v", err)
+		logger.ErrorContext(ctx, "error flushing to dir-file.txt", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	log.Println("Collecting vpk_dir file...")
+	logger.InfoContext(ctx, "collecting vpk_dir file")
 
 	dirResult := cmdrunner.RunCommand("tools/DepotDownloader",
-		"-app", "730",
-		"-depot", "2347770",
+		"-app", AppID,
+		"-depot", DepotID,
 		"-dir", "data",
 		"-filelist", dirFile.Name(),
 	)
 	if dirResult.Error != nil {
-		log.Fatalf("DepotDownloader encountered an error while processing filelist: %v", dirResult.Error)
+		logger.ErrorContext(ctx, "DepotDownloader encountered an error while processing filelist", slog.Any("error", dirResult.Error))
+		os.Exit(1)
 	}
 
 	// ---------------------------------------
@@ -127,53 +180,203 @@ func main() {
 	// ---------------------------------------
 	fileList, err := os.CreateTemp("", "filelist_*.txt")
 	if err != nil {
-		log.Fatalf("Error creating temporary file: %v", err)
+		logger.ErrorContext(ctx, "error creating temporary file", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer os.Remove(fileList.Name())
 	defer fileList.Close()
 
 	err = parser.GenerateVPKList(VPKDir, ImageDir, BaseDir, fileList.Name())
 	if err != nil {
-		log.Fatalf("Failed to generate VPK list: %v", err)
+		logger.ErrorContext(ctx, "failed to generate VPK list", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	// ---------------------------------------
-	// 7) Run DepotDownloader to get the VPK files
+	// 7) Run DepotDownloader to get the VPK files, one archive at a time,
+	//    through the download cache so an unchanged archive is hard-linked
+	//    from a prior run instead of being re-fetched over Steam.
 	// ---------------------------------------
-	downloadResult := cmdrunner.PipeOutput("tools/DepotDownloader",
-		"-app", "730",
-		"-depot", "2347770",
-		"-dir", "data",
-		"-filelist", fileList.Name(),
-	)
-	if downloadResult != nil {
-		log.Fatalf("DepotDownloader encountered an error: %v", downloadResult)
+	archives, err := readLines(fileList.Name())
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to read VPK archive list", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	downloadCache, err := cache.New(*cacheDir)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to initialize download cache", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	log.Println("Downloading files...")
-	log.Println("Finished downloading files.")
+	logger.InfoContext(ctx, "downloading files", slog.Int("archives", len(archives)))
+	for _, archive := range archives {
+		if err := fetchArchive(ctx, downloadCache, newManifestID, archive, *noCache, *verifyCache); err != nil {
+			logger.ErrorContext(ctx, "failed to fetch VPK archive", slog.String("archive", archive), slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	logger.InfoContext(ctx, "finished downloading files")
 
 	// ---------------------------------------
-	// 8) Run Source2Viewer-CLI to extract the files
+	// 8) Extract the image files from the VPK
 	// ---------------------------------------
-	extractResult := cmdrunner.PipeOutput("tools/Source2Viewer-CLI",
-		"-i", VPKDir,
-		"-o", "static",
-		"-d",
-		"--vpk_filepath", ImageDir,
-	)
-	if extractResult != nil {
-		log.Fatalf("Source2Viewer-CLI encountered an error: %v", extractResult)
+	logger.InfoContext(ctx, "extracting files")
+	if err := parser.ExtractImages(VPKDir, ImageDir, "static"); err != nil {
+		logger.ErrorContext(ctx, "failed to extract images", slog.Any("error", err))
+		os.Exit(1)
 	}
-	log.Println("Extracting files...")
 
-	// Rename files
-	log.Println("Renaming files...")
-	findAndRenameFiles()
+	// ---------------------------------------
+	// 9) Discover plugins and run the post-extract / pre-publish /
+	//    post-publish hooks around renaming and publishing.
+	// ---------------------------------------
+	manifests, err := plugin.Discover(*pluginsDir)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to discover plugins", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	logger.InfoContext(ctx, "running post-extract hooks")
+	runHook(ctx, logger, manifests, plugin.HookPostExtract, "static", newManifestID)
+
+	logger.InfoContext(ctx, "running pre-publish hooks")
+	prePublish := runHook(ctx, logger, manifests, plugin.HookPrePublish, "static", newManifestID)
 
 	// Add images to CDN list
-	log.Println("Adding images to CDN list...")
-	addImagesToCDN()
+	logger.InfoContext(ctx, "adding images to CDN list")
+	addImagesToCDN(prePublish)
+
+	logger.InfoContext(ctx, "running post-publish hooks")
+	runHook(ctx, logger, manifests, plugin.HookPostPublish, "static", newManifestID)
+}
+
+// runHook invokes every plugin matching hook, applies the renames and
+// deletions it reports against root, and returns the raw responses so
+// callers can act on fields runHook itself doesn't apply (e.g.
+// Response.CDNEntries). A plugin error or a failed rename aborts the run,
+// since continuing with a partially-applied hook would leave the
+// extraction root inconsistent with what a plugin expected.
+func runHook(ctx context.Context, logger *slog.Logger, manifests []plugin.Manifest, hook plugin.Hook, root, manifestID string) []plugin.Response {
+	responses, err := plugin.Invoke(ctx, manifests, hook, plugin.Request{Root: root, ManifestID: manifestID})
+	if err != nil {
+		logger.ErrorContext(ctx, "plugin hook failed", slog.String("hook", string(hook)), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	for _, resp := range responses {
+		for _, r := range resp.Renames {
+			from := filepath.Join(root, filepath.FromSlash(r.From))
+			to := filepath.Join(root, filepath.FromSlash(r.To))
+			if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+				logger.ErrorContext(ctx, "failed to create directory for rename", slog.String("to", to), slog.Any("error", err))
+				os.Exit(1)
+			}
+			if err := os.Rename(from, to); err != nil {
+				logger.ErrorContext(ctx, "failed to apply plugin rename", slog.String("from", from), slog.String("to", to), slog.Any("error", err))
+				os.Exit(1)
+			}
+		}
+		for _, d := range resp.Deletions {
+			path := filepath.Join(root, filepath.FromSlash(d))
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				logger.ErrorContext(ctx, "failed to apply plugin deletion", slog.String("path", path), slog.Any("error", err))
+				os.Exit(1)
+			}
+		}
+	}
+
+	return responses
+}
+
+// readLines returns the non-empty, trimmed lines of path.
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// fetchArchive ensures archive (a depot-relative path such as
+// "game/csgo/pak01_003.vpk") is present at data/archive, downloading it
+// through DepotDownloader on a cache miss and hard-linking the cached copy
+// into place otherwise. Archives are cached per manifest ID, since the
+// same archive name holds different bytes across manifests.
+func fetchArchive(ctx context.Context, c *cache.Cache, manifestID, archive string, noCache, verify bool) error {
+	dest := filepath.Join("data", filepath.FromSlash(archive))
+
+	if noCache {
+		return downloadArchive(ctx, archive, "data")
+	}
+
+	key := manifestID + ":" + archive
+	if _, err := c.Get(ctx, key, verify, func(ctx context.Context) (io.ReadCloser, string, error) {
+		tmpDir, err := os.MkdirTemp("", "vpk-archive-*")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err := downloadArchive(ctx, archive, tmpDir); err != nil {
+			return nil, "", err
+		}
+
+		f, err := os.Open(filepath.Join(tmpDir, filepath.FromSlash(archive)))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open downloaded archive: %w", err)
+		}
+		return f, "", nil
+	}); err != nil {
+		return err
+	}
+
+	return c.Link(key, dest)
+}
+
+// downloadArchive runs DepotDownloader to fetch the single depot-relative
+// file archive into destDir.
+func downloadArchive(ctx context.Context, archive, destDir string) error {
+	fileList, err := os.CreateTemp("", "filelist_*.txt")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(fileList.Name())
+	defer fileList.Close()
+
+	writer := bufio.NewWriter(fileList)
+	if _, err := writer.WriteString(archive + "\n"); err != nil {
+		return fmt.Errorf("error writing filelist: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error flushing filelist: %w", err)
+	}
+
+	// Archive downloads run far longer than cmdrunner.RunCommand's
+	// 60-second default allows, so this builds its own timeout and calls
+	// the runner directly rather than going through RunCommand.
+	downloadCtx, cancel := context.WithTimeout(ctx, archiveDownloadTimeout)
+	defer cancel()
+
+	runner := &cmdrunner.DefaultRunner{}
+	result := runner.Run(downloadCtx, "tools/DepotDownloader",
+		"-app", AppID,
+		"-depot", DepotID,
+		"-dir", destDir,
+		"-filelist", fileList.Name(),
+	)
+	if result.Error != nil {
+		return fmt.Errorf("DepotDownloader encountered an error: %w", result.Error)
+	}
+	return nil
 }
 
 // fileExists is a helper that returns true if a file exists (and is not a directory).
@@ -186,67 +389,106 @@ func fileExists(filename string) bool {
 }
 
 func checkDependencies() {
-	if err := dependencies.EnsureTools(); err != nil {
-		log.Fatalf("Failed to ensure tools: %v", err)
+	downloadCache, err := cache.New(*cacheDir)
+	if err != nil {
+		slog.Error("failed to initialize download cache", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	log.Println("All dependencies are satisfied.")
-}
+	opts := dependencies.Options{
+		Cache:   downloadCache,
+		NoCache: *noCache,
+		Verify:  *verifyCache,
+	}
+	if err := dependencies.EnsureToolsWithOptions(opts); err != nil {
+		slog.Error("failed to ensure tools", slog.Any("error", err))
+		os.Exit(1)
+	}
 
-func findAndRenameFiles() {
-	err := filepath.WalkDir("static", func(path string, file fs.DirEntry, err error) error {
-		if err != nil {
-			log.Printf("Error accessing path %q: %v\n", path, err)
-			return nil
-		}
+	slog.Info("all dependencies are satisfied")
+}
 
-		if strings.HasSuffix(file.Name(), ".png") {
-			// Remove any "_png" substring from the file name
-			newName := strings.ReplaceAll(file.Name(), "_png", "")
-			err := os.Rename(path, filepath.Join(filepath.Dir(path), newName))
-			if err != nil {
-				return err
-			}
-		}
+// addImagesToCDN publishes every PNG under "static" to the configured
+// storage backend and records its URL in cdn.json. prePublishResponses'
+// CDNEntries are recorded as-is and skipped in the normal publish walk,
+// for plugins that published those keys themselves instead of letting
+// this function do it.
+func addImagesToCDN(prePublishResponses []plugin.Response) {
+	cdnListPath := "cdn.json"
 
-		return nil
-	})
+	cfg, err := storage.LoadConfig(*storageConfigPath)
 	if err != nil {
-		log.Fatalf("Error walking the path: %v\n", err)
+		slog.Error("error loading storage config", slog.Any("error", err))
+		os.Exit(1)
 	}
-}
 
-func addImagesToCDN() {
-	baseURL := "https://cdn.jsdelivr.net/gh/SkinDelta/go-cs2-cdn@main/"
-	cdnListPath := "cdn.json"
+	backend, err := storage.NewFromConfig(cfg)
+	if err != nil {
+		slog.Error("error constructing storage backend", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// Read existing file entries
 	data, err := os.ReadFile(cdnListPath)
 	if err != nil {
-		log.Fatalf("Error reading cdn.json: %v\n", err)
+		slog.Error("error reading cdn.json", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	entries := make(map[string]string)
 	_ = json.Unmarshal(data, &entries)
 
-	// Walk the "static" directory
+	// Plugin-published entries take their URL as given and are skipped
+	// below, since the plugin already handled publishing them. Their keys
+	// are relative to "static" (the hook's Root), matching the "static/..."
+	// keys the walk below records entries under.
+	pluginPublished := make(map[string]struct{})
+	for _, resp := range prePublishResponses {
+		for key, url := range resp.CDNEntries {
+			path := filepath.Join("static", filepath.FromSlash(key))
+			entries[path] = url
+			pluginPublished[path] = struct{}{}
+		}
+	}
+
+	ctx := context.Background()
+
+	// Walk the "static" directory, publishing each PNG to the backend and
+	// recording the backend's public URL for it.
 	err = filepath.WalkDir("static", func(path string, file fs.DirEntry, werr error) error {
 		if werr != nil {
-			log.Printf("Error accessing path %q: %v\n", path, werr)
+			slog.Warn("error accessing path", slog.String("path", path), slog.Any("error", werr))
 			return nil
 		}
-		if !file.IsDir() && strings.HasSuffix(strings.ToLower(file.Name()), ".png") {
-			entries[path] = baseURL + path
+		if file.IsDir() || !strings.HasSuffix(strings.ToLower(file.Name()), ".png") {
+			return nil
+		}
+		if _, ok := pluginPublished[path]; ok {
+			return nil
 		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		key := filepath.ToSlash(path)
+		if err := backend.PutFile(ctx, key, f, storage.Metadata{ContentType: "image/png"}); err != nil {
+			return fmt.Errorf("failed to publish %s: %w", path, err)
+		}
+
+		entries[path] = backend.URL(key)
 		return nil
 	})
 	if err != nil {
-		log.Printf("Error walking dir: %v\n", err)
+		slog.Warn("error walking dir", slog.Any("error", err))
 	}
 
 	// Write updated entries
 	updated, _ := json.MarshalIndent(entries, "", "  ")
 	if err := os.WriteFile(cdnListPath, updated, 0644); err != nil {
-		log.Fatalf("Error writing cdn.json: %v\n", err)
+		slog.Error("error writing cdn.json", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
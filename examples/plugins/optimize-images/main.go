@@ -0,0 +1,60 @@
+// optimize-images is an example post-extract plugin. It shells out to
+// "optipng" to losslessly shrink every .png under the extraction root
+// in place, before any renaming or publishing happens.
+//
+// It's meant as a template for third-party plugins, not a built-in: it is
+// not wired into go-cs2-cdn by default and only runs if listed under
+// hooks in a deployment's plugins/ directory.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// request mirrors internal/plugin.Request.
+type request struct {
+	Root       string   `json:"root"`
+	ManifestID string   `json:"manifest_id"`
+	Files      []string `json:"files"`
+}
+
+// response mirrors internal/plugin.Response. optimize-images only
+// rewrites files in place, so it has nothing to report back.
+type response struct{}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "optimize-images:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	err := filepath.WalkDir(req.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".png") {
+			return err
+		}
+
+		cmd := exec.Command("optipng", "-quiet", "-o2", path)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to optimize %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", req.Root, err)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(response{})
+}
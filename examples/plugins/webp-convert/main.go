@@ -0,0 +1,63 @@
+// webp-convert is an example pre-publish plugin. It shells out to the
+// system "cwebp" encoder to produce a .webp copy alongside every .png
+// under the extraction root. It does not report any CDNEntries back, so
+// the .webp files it leaves behind are not published anywhere on their
+// own; go-cs2-cdn's normal publish step only ever walks for .png files.
+//
+// It's meant as a template for third-party plugins, not a built-in: it is
+// not wired into go-cs2-cdn by default and only runs if listed under
+// hooks in a deployment's plugins/ directory.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// request mirrors internal/plugin.Request.
+type request struct {
+	Root       string   `json:"root"`
+	ManifestID string   `json:"manifest_id"`
+	Files      []string `json:"files"`
+}
+
+// response mirrors internal/plugin.Response. webp-convert only adds files
+// alongside the originals, so it has nothing to report back.
+type response struct{}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "webp-convert:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	err := filepath.WalkDir(req.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".png") {
+			return err
+		}
+
+		webpPath := strings.TrimSuffix(path, ".png") + ".webp"
+		cmd := exec.Command("cwebp", "-quiet", path, "-o", webpPath)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to convert %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", req.Root, err)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(response{})
+}
@@ -0,0 +1,239 @@
+// cache/cache.go
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+	"golang.org/x/sync/singleflight"
+)
+
+// Entry is the sidecar metadata stored next to each cached artifact.
+type Entry struct {
+	Key       string    `json:"key"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	SourceURL string    `json:"source_url,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache is a content-addressed, on-disk cache of downloaded artifacts,
+// keyed by an arbitrary caller-chosen key (typically a manifest ID or a
+// GitHub release asset's own SHA). Entries are stored under
+// Dir/<sha256(key)> with a Dir/<sha256(key)>.json sidecar recording the
+// artifact's hash, size and source. An in-memory index avoids re-reading
+// every sidecar on repeated lookups within a single run.
+type Cache struct {
+	Dir string
+
+	index *xsync.MapOf[string, Entry]
+	group singleflight.Group
+}
+
+// New returns a Cache rooted at dir, creating it if necessary. A zero
+// value dir defaults to ~/.cache/go-cs2-cdn/downloads.
+func New(dir string) (*Cache, error) {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("cache: failed to resolve home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".cache", "go-cs2-cdn", "downloads")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cache: failed to create cache directory %s: %w", dir, err)
+	}
+
+	return &Cache{Dir: dir, index: xsync.NewMapOf[string, Entry]()}, nil
+}
+
+func (c *Cache) hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) artifactPath(hashedKey string) string { return filepath.Join(c.Dir, hashedKey) }
+func (c *Cache) sidecarPath(hashedKey string) string {
+	return filepath.Join(c.Dir, hashedKey+".json")
+}
+
+// lookup returns the cached Entry for key, if present and valid, reading
+// through to the sidecar file on an in-memory miss.
+func (c *Cache) lookup(hashedKey string) (Entry, bool) {
+	if e, ok := c.index.Load(hashedKey); ok {
+		return e, true
+	}
+
+	data, err := os.ReadFile(c.sidecarPath(hashedKey))
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, false
+	}
+	if _, err := os.Stat(c.artifactPath(hashedKey)); err != nil {
+		return Entry{}, false
+	}
+
+	c.index.Store(hashedKey, e)
+	return e, true
+}
+
+// Get returns the path to the cached artifact for key, calling fetcher to
+// populate the cache on a miss. Concurrent calls for the same key share a
+// single in-flight fetch. When verify is true, an existing cache entry's
+// SHA-256 is recomputed and compared against the sidecar before it is
+// trusted; a mismatch triggers a re-fetch.
+func (c *Cache) Get(ctx context.Context, key string, verify bool, fetcher func(ctx context.Context) (io.ReadCloser, string, error)) (string, error) {
+	hashedKey := c.hashKey(key)
+
+	if e, ok := c.lookup(hashedKey); ok {
+		path := c.artifactPath(hashedKey)
+		if !verify {
+			return path, nil
+		}
+		if err := c.verify(path, e); err == nil {
+			return path, nil
+		}
+		// Fall through to re-fetch a corrupted or tampered entry.
+	}
+
+	v, err, _ := c.group.Do(hashedKey, func() (interface{}, error) {
+		return c.fetchAndStore(ctx, hashedKey, key, fetcher)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (c *Cache) fetchAndStore(ctx context.Context, hashedKey, key string, fetcher func(ctx context.Context) (io.ReadCloser, string, error)) (string, error) {
+	r, sourceURL, err := fetcher(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cache: fetch failed for %s: %w", key, err)
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp(c.Dir, "download-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("cache: failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed into place
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	tmp.Close()
+	if err != nil {
+		return "", fmt.Errorf("cache: failed to write %s: %w", key, err)
+	}
+
+	artifactPath := c.artifactPath(hashedKey)
+	if err := os.Rename(tmpPath, artifactPath); err != nil {
+		return "", fmt.Errorf("cache: failed to install %s into cache: %w", key, err)
+	}
+
+	entry := Entry{
+		Key:       key,
+		SHA256:    hex.EncodeToString(hasher.Sum(nil)),
+		Size:      size,
+		SourceURL: sourceURL,
+		FetchedAt: time.Now(),
+	}
+
+	sidecar, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("cache: failed to marshal sidecar for %s: %w", key, err)
+	}
+	if err := os.WriteFile(c.sidecarPath(hashedKey), sidecar, 0644); err != nil {
+		return "", fmt.Errorf("cache: failed to write sidecar for %s: %w", key, err)
+	}
+
+	c.index.Store(hashedKey, entry)
+	return artifactPath, nil
+}
+
+// verify recomputes the SHA-256 of path and compares it against e.SHA256.
+func (c *Cache) verify(path string, e Entry) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cache: failed to open %s for verification: %w", path, err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return fmt.Errorf("cache: failed to hash %s: %w", path, err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if sum != e.SHA256 {
+		return fmt.Errorf("cache: checksum mismatch for %s: expected %s, got %s", path, e.SHA256, sum)
+	}
+	return nil
+}
+
+// Link hard-links the cached artifact for key into dest, falling back to
+// a copy when the cache and destination are on different filesystems.
+func (c *Cache) Link(key, dest string) error {
+	hashedKey := c.hashKey(key)
+	e, ok := c.lookup(hashedKey)
+	if !ok {
+		return fmt.Errorf("cache: no cached entry for key %q", key)
+	}
+
+	src := c.artifactPath(hashedKey)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("cache: failed to create directory for %s: %w", dest, err)
+	}
+
+	os.Remove(dest) // Link fails if dest already exists.
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	// Cross-device or unsupported; fall back to a copy.
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cache: failed to open cached %s: %w", key, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("cache: failed to create %s: %w", dest, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("cache: failed to copy cached %s to %s: %w", key, dest, err)
+	}
+
+	_ = e // size/hash already validated by lookup's caller when verify is requested
+	return nil
+}
+
+// Evict removes the cached artifact and sidecar for key.
+func (c *Cache) Evict(key string) error {
+	hashedKey := c.hashKey(key)
+	c.index.Delete(hashedKey)
+
+	if err := os.Remove(c.artifactPath(hashedKey)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cache: failed to remove artifact for %q: %w", key, err)
+	}
+	if err := os.Remove(c.sidecarPath(hashedKey)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cache: failed to remove sidecar for %q: %w", key, err)
+	}
+	return nil
+}
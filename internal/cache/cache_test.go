@@ -0,0 +1,162 @@
+// cache/cache_test.go
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newFetcher(body string) func(ctx context.Context) (io.ReadCloser, string, error) {
+	return func(ctx context.Context) (io.ReadCloser, string, error) {
+		return io.NopCloser(strings.NewReader(body)), "https://example.com/artifact", nil
+	}
+}
+
+func TestGetFetchesOnceAndReusesCache(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	calls := 0
+	fetcher := func(ctx context.Context) (io.ReadCloser, string, error) {
+		calls++
+		return io.NopCloser(strings.NewReader("payload")), "", nil
+	}
+
+	path1, err := c.Get(context.Background(), "key", false, fetcher)
+	if err != nil {
+		t.Fatalf("Get (miss): %v", err)
+	}
+	data, err := os.ReadFile(path1)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("cached content = %q, want %q", data, "payload")
+	}
+
+	path2, err := c.Get(context.Background(), "key", false, fetcher)
+	if err != nil {
+		t.Fatalf("Get (hit): %v", err)
+	}
+	if path1 != path2 {
+		t.Errorf("Get returned different paths on hit: %q vs %q", path1, path2)
+	}
+	if calls != 1 {
+		t.Errorf("fetcher called %d times, want 1", calls)
+	}
+}
+
+func TestGetVerifyRefetchesCorruptedEntry(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	path, err := c.Get(context.Background(), "key", false, newFetcher("payload"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("tampered"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	calls := 0
+	fetcher := func(ctx context.Context) (io.ReadCloser, string, error) {
+		calls++
+		return io.NopCloser(strings.NewReader("payload")), "", nil
+	}
+	if _, err := c.Get(context.Background(), "key", true, fetcher); err != nil {
+		t.Fatalf("Get (verify): %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fetcher called %d times after tampering, want 1 re-fetch", calls)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("content after re-fetch = %q, want %q", data, "payload")
+	}
+}
+
+func TestGetPropagatesFetcherError(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	wantErr := errors.New("boom")
+	_, err = c.Get(context.Background(), "key", false, func(ctx context.Context) (io.ReadCloser, string, error) {
+		return nil, "", wantErr
+	})
+	if err == nil || !errors.Is(err, wantErr) {
+		t.Errorf("Get error = %v, want wrapped %v", err, wantErr)
+	}
+}
+
+func TestLink(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := c.Get(context.Background(), "key", false, newFetcher("payload")); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	dest := dir + "/linked/out.bin"
+	if err := c.Link("key", dest); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("linked content = %q, want %q", data, "payload")
+	}
+}
+
+func TestLinkUnknownKey(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := c.Link("missing", t.TempDir()+"/out.bin"); err == nil {
+		t.Error("Link with unknown key = nil error, want error")
+	}
+}
+
+func TestEvict(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	path, err := c.Get(context.Background(), "key", false, newFetcher("payload"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if err := c.Evict("key"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("artifact still exists after Evict, stat err = %v", err)
+	}
+
+	// Evicting an already-absent key is not an error.
+	if err := c.Evict("key"); err != nil {
+		t.Errorf("Evict of already-evicted key: %v", err)
+	}
+}
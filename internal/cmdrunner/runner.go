@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -28,27 +30,38 @@ type DefaultRunner struct{}
 
 // Run executes the command with the given name and arguments.
 // It captures stdout and stderr, respects the provided context for timeouts and cancellations.
+// Output is also streamed line-by-line to slog at debug level as the command runs, rather than
+// dumped in one block once the command exits.
 func (r *DefaultRunner) Run(ctx context.Context, name string, args ...string) CommandResult {
-	var stdoutBuf, stderrBuf bytes.Buffer
-
 	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
 
-	log.Printf("Executing command: %s %s", name, strings.Join(args, " "))
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("failed to get stdout pipe: %w", err)}
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("failed to get stderr pipe: %w", err)}
+	}
+
+	slog.DebugContext(ctx, "executing command", slog.String("cmd", name), slog.String("args", strings.Join(args, " ")))
 
-	err := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		return CommandResult{Error: fmt.Errorf("command execution failed: %w", err)}
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go streamAndCapture(ctx, stdoutPipe, &stdoutBuf, name, "stdout", &wg)
+	go streamAndCapture(ctx, stderrPipe, &stderrBuf, name, "stderr", &wg)
+	wg.Wait()
+
+	err = cmd.Wait()
 
 	stdoutStr := stdoutBuf.String()
 	stderrStr := stderrBuf.String()
 
-	if stdoutStr != "" {
-		log.Printf("Command stdout: %s", stdoutStr)
-	}
-	if stderrStr != "" {
-		log.Printf("Command stderr: %s", stderrStr)
-	}
-
 	if err != nil {
 		// Check if the error is due to context timeout
 		if ctx.Err() == context.DeadlineExceeded {
@@ -72,6 +85,71 @@ func (r *DefaultRunner) Run(ctx context.Context, name string, args ...string) Co
 	}
 }
 
+// RunOptions configures RunWithOptions beyond the plain name/args a
+// simple Run call takes.
+type RunOptions struct {
+	// Dir, if set, is the working directory the command runs in.
+	Dir string
+	// Stdin, if set, is piped to the command's standard input.
+	Stdin io.Reader
+}
+
+// RunWithOptions behaves like (*DefaultRunner).Run but additionally
+// supports a working directory and a stdin stream, for commands (such as
+// plugin hooks) that take their input as a JSON document on stdin rather
+// than as arguments.
+func RunWithOptions(ctx context.Context, name string, args []string, opts RunOptions) CommandResult {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = opts.Dir
+	cmd.Stdin = opts.Stdin
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("failed to get stdout pipe: %w", err)}
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("failed to get stderr pipe: %w", err)}
+	}
+
+	slog.DebugContext(ctx, "executing command", slog.String("cmd", name), slog.String("args", strings.Join(args, " ")), slog.String("dir", opts.Dir))
+
+	if err := cmd.Start(); err != nil {
+		return CommandResult{Error: fmt.Errorf("command execution failed: %w", err)}
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go streamAndCapture(ctx, stdoutPipe, &stdoutBuf, name, "stdout", &wg)
+	go streamAndCapture(ctx, stderrPipe, &stderrBuf, name, "stderr", &wg)
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return CommandResult{Stdout: stdoutBuf.String(), Stderr: stderrBuf.String(), Error: fmt.Errorf("command execution failed: %w", err)}
+	}
+
+	return CommandResult{Stdout: stdoutBuf.String(), Stderr: stderrBuf.String()}
+}
+
+// streamAndCapture scans r line-by-line, appending each line to buf and
+// emitting it as a debug-level slog record grouped under "cmd".
+func streamAndCapture(ctx context.Context, r io.Reader, buf *bytes.Buffer, cmdName, stream string, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+
+		slog.DebugContext(ctx, "command output",
+			slog.Group("cmd", slog.String("name", cmdName), slog.String("stream", stream)),
+			slog.String("line", line),
+		)
+	}
+}
+
 // RunCommand is a helper function to execute a command with a default timeout.
 func RunCommand(name string, args ...string) CommandResult {
 	runner := &DefaultRunner{}
@@ -104,7 +182,7 @@ func PipeOutput(name string, args ...string) error {
 	go func() {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
-			log.Printf("[stdout] %s", scanner.Text())
+			slog.Debug("command output", slog.Group("cmd", slog.String("name", name), slog.String("stream", "stdout")), slog.String("line", scanner.Text()))
 		}
 	}()
 
@@ -112,7 +190,7 @@ func PipeOutput(name string, args ...string) error {
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
-			log.Printf("[stderr] %s", scanner.Text())
+			slog.Debug("command output", slog.Group("cmd", slog.String("name", name), slog.String("stream", "stderr")), slog.String("line", scanner.Text()))
 		}
 	}()
 
@@ -0,0 +1,68 @@
+// dependencies/auth.go
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"golang.org/x/oauth2"
+)
+
+const (
+	envGitHubToken            = "GITHUB_TOKEN"
+	envGitHubAppID            = "GITHUB_APP_ID"
+	envGitHubAppInstallID     = "GITHUB_APP_INSTALLATION_ID"
+	envGitHubAppPrivateKey    = "GITHUB_APP_PRIVATE_KEY"
+	envGitHubAppPrivateKeyPat = "GITHUB_APP_PRIVATE_KEY_PATH"
+)
+
+// NewGitHubClient builds an *http.Client authenticated against the GitHub
+// API, preferring a GitHub App installation (GITHUB_APP_ID +
+// GITHUB_APP_INSTALLATION_ID + a PEM private key from
+// GITHUB_APP_PRIVATE_KEY_PATH or GITHUB_APP_PRIVATE_KEY) over a personal
+// access token (GITHUB_TOKEN). If neither is configured, it returns
+// http.DefaultClient so unauthenticated (rate-limited) requests still work
+// for local, occasional use.
+func NewGitHubClient(ctx context.Context) (*http.Client, error) {
+	if appID := os.Getenv(envGitHubAppID); appID != "" {
+		return newGitHubAppClient(appID)
+	}
+
+	if token := os.Getenv(envGitHubToken); token != "" {
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		return oauth2.NewClient(ctx, ts), nil
+	}
+
+	return http.DefaultClient, nil
+}
+
+func newGitHubAppClient(appIDStr string) (*http.Client, error) {
+	appID, err := strconv.ParseInt(appIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("auth: invalid %s %q: %w", envGitHubAppID, appIDStr, err)
+	}
+
+	installIDStr := os.Getenv(envGitHubAppInstallID)
+	installID, err := strconv.ParseInt(installIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("auth: invalid %s %q: %w", envGitHubAppInstallID, installIDStr, err)
+	}
+
+	var transport *ghinstallation.Transport
+	if keyPath := os.Getenv(envGitHubAppPrivateKeyPat); keyPath != "" {
+		transport, err = ghinstallation.NewKeyFromFile(http.DefaultTransport, appID, installID, keyPath)
+	} else if key := os.Getenv(envGitHubAppPrivateKey); key != "" {
+		transport, err = ghinstallation.New(http.DefaultTransport, appID, installID, []byte(key))
+	} else {
+		return nil, fmt.Errorf("auth: %s is set but neither %s nor %s is", envGitHubAppID, envGitHubAppPrivateKeyPat, envGitHubAppPrivateKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: failed to build GitHub App transport: %w", err)
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
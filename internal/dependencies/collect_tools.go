@@ -4,15 +4,18 @@ package dependencies
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/SkinDelta/go-cs2-cdn/internal/cache"
 )
 
 // Tool represents a downloadable tool with its GitHub repository and asset details.
@@ -22,9 +25,47 @@ type Tool struct {
 	RepoName        string
 	AssetNameSuffix string
 	ExecutableName  string
+	// PinnedVersion, if set, is a release tag (e.g. "v2.7.1") to fetch
+	// instead of "latest", so builds are reproducible across runs.
+	PinnedVersion string
+}
+
+// Fetch retrieves the GitHub release this Tool should install: the
+// release tagged PinnedVersion if set, otherwise the latest release.
+// client is expected to be authenticated (see NewGitHubClient) so CI runs
+// aren't limited to 60 unauthenticated requests/hour.
+func (t Tool) Fetch(ctx context.Context, client *http.Client) (*Release, error) {
+	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", t.RepoOwner, t.RepoName)
+	if t.PinnedVersion != "" {
+		releaseURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", t.RepoOwner, t.RepoName, t.PinnedVersion)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch release: status code %d", resp.StatusCode)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release JSON: %w", err)
+	}
+	return &release, nil
 }
 
-// List of tools to manage
+// List of tools to manage. Source2Viewer-CLI is still required to
+// decompile the raw ".vtex_c" resources internal/vpk extracts into real
+// PNG pixel data; only VPK reading and extraction itself moved natively.
 var tools = []Tool{
 	{
 		Name:            "DepotDownloader",
@@ -42,9 +83,32 @@ var tools = []Tool{
 	},
 }
 
+// Options configures EnsureTools' use of the download cache and GitHub authentication.
+type Options struct {
+	// Cache, if non-nil, is used to avoid re-downloading release assets
+	// that have already been fetched in a previous run.
+	Cache *cache.Cache
+	// NoCache forces a re-download even if a cache entry exists.
+	NoCache bool
+	// Verify recomputes and checks cached assets' SHA-256 before reuse.
+	Verify bool
+	// GitHubClient, if non-nil, is used for the GitHub API release lookup
+	// instead of constructing one from NewGitHubClient. Set this to reuse
+	// a client across calls or to inject a test double.
+	GitHubClient *http.Client
+}
+
 // EnsureTools ensures that all required tools are present.
 // If a tool is missing, it downloads and installs it.
 func EnsureTools() error {
+	return EnsureToolsWithOptions(Options{})
+}
+
+// EnsureToolsWithOptions behaves like EnsureTools but routes asset
+// downloads through opts.Cache when one is provided, and authenticates
+// release lookups via opts.GitHubClient (or NewGitHubClient) to avoid the
+// unauthenticated API's 60 requests/hour rate limit.
+func EnsureToolsWithOptions(opts Options) error {
 	toolsDir := "tools"
 
 	// Create tools directory if it doesn't exist
@@ -52,16 +116,24 @@ func EnsureTools() error {
 		return fmt.Errorf("failed to create tools directory: %w", err)
 	}
 
+	if opts.GitHubClient == nil {
+		client, err := NewGitHubClient(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to build GitHub client: %w", err)
+		}
+		opts.GitHubClient = client
+	}
+
 	for _, tool := range tools {
 		execPath := filepath.Join(toolsDir, tool.ExecutableName)
 		if !fileExists(execPath) {
-			log.Printf("Tool %s not found. Downloading...", tool.Name)
-			if err := downloadAndInstallTool(tool, toolsDir); err != nil {
+			slog.Info("tool not found, downloading", slog.String("tool", tool.Name))
+			if err := downloadAndInstallTool(tool, toolsDir, opts); err != nil {
 				return fmt.Errorf("failed to download %s: %w", tool.Name, err)
 			}
-			log.Printf("Tool %s downloaded and installed successfully.", tool.Name)
+			slog.Info("tool downloaded and installed", slog.String("tool", tool.Name))
 		} else {
-			log.Printf("Tool %s already exists. Skipping download.", tool.Name)
+			slog.Info("tool already exists, skipping download", slog.String("tool", tool.Name))
 		}
 	}
 
@@ -102,33 +174,15 @@ func checkGOARCH() string {
 
 // downloadAndInstallTool downloads the latest release asset matching the tool's AssetNameSuffix,
 // extracts it, and places the executable in the tools directory.
-func downloadAndInstallTool(tool Tool, toolsDir string) error {
+func downloadAndInstallTool(tool Tool, toolsDir string, opts Options) error {
 	// Determine the OS-specific asset name suffix
 	tool.AssetNameSuffix = strings.ReplaceAll(tool.AssetNameSuffix, "linux", checkOS())
 	tool.AssetNameSuffix = strings.ReplaceAll(tool.AssetNameSuffix, "x64", checkGOARCH())
 
-	// Step 1: Get the latest release
-	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", tool.RepoOwner, tool.RepoName)
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", releaseURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := client.Do(req)
+	// Step 1: Get the release to install (latest, or PinnedVersion if set)
+	release, err := tool.Fetch(context.Background(), opts.GitHubClient)
 	if err != nil {
-		return fmt.Errorf("failed to fetch latest release: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to fetch latest release: status code %d", resp.StatusCode)
-	}
-
-	// Parse the JSON response to find the asset URL
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return fmt.Errorf("failed to decode release JSON: %w", err)
+		return fmt.Errorf("failed to fetch release: %w", err)
 	}
 
 	assetURL := ""
@@ -143,26 +197,74 @@ func downloadAndInstallTool(tool Tool, toolsDir string) error {
 		return fmt.Errorf("asset with suffix %s not found in latest release", tool.AssetNameSuffix)
 	}
 
-	// Step 2: Download the asset
-	log.Printf("Downloading %s from %s", tool.Name, assetURL)
-	assetResp, err := http.Get(assetURL)
+	// Step 2: Download the asset, reusing a cached copy when available.
+	zipPath, err := fetchAsset(assetURL, opts)
 	if err != nil {
-		return fmt.Errorf("failed to download asset: %w", err)
+		return err
 	}
-	defer assetResp.Body.Close()
 
-	if assetResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download asset: status code %d", assetResp.StatusCode)
+	zipFile, err := os.Open(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open downloaded asset: %w", err)
 	}
+	defer zipFile.Close()
 
 	// Step 3: Extract all files from the ZIP archive
-	log.Printf("Extracting %s...", tool.Name)
-	if err := extractZip(assetResp.Body, toolsDir); err != nil {
+	slog.Info("extracting tool", slog.String("tool", tool.Name))
+	if err := extractZip(zipFile, toolsDir); err != nil {
 		return fmt.Errorf("failed to extract zip: %w", err)
 	}
 	return nil
 }
 
+// fetchAsset downloads assetURL, returning the path to the downloaded file.
+// When opts.Cache is set, the download is keyed by assetURL so repeated
+// runs (e.g. in CI) reuse the artifact instead of re-downloading it; a
+// cache hit's checksum is re-verified when opts.Verify is set.
+func fetchAsset(assetURL string, opts Options) (string, error) {
+	if opts.Cache == nil || opts.NoCache {
+		return downloadToTemp(assetURL)
+	}
+
+	return opts.Cache.Get(context.Background(), assetURL, opts.Verify, func(ctx context.Context) (io.ReadCloser, string, error) {
+		slog.DebugContext(ctx, "downloading asset (cache miss)", slog.String("url", assetURL))
+		resp, err := http.Get(assetURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to download asset: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, "", fmt.Errorf("failed to download asset: status code %d", resp.StatusCode)
+		}
+		return resp.Body, assetURL, nil
+	})
+}
+
+// downloadToTemp downloads assetURL straight to a temporary file, bypassing the cache.
+func downloadToTemp(assetURL string) (string, error) {
+	slog.Debug("downloading asset", slog.String("url", assetURL))
+	resp, err := http.Get(assetURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download asset: status code %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp("", "asset-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to write downloaded asset: %w", err)
+	}
+	return tmp.Name(), nil
+}
+
 // extractZip extracts all files from a ZIP archive and saves them to the tools directory.
 // It preserves the internal directory structure of the ZIP archive.
 func extractZip(zipReader io.Reader, toolsDir string) error {
@@ -230,7 +332,7 @@ func extractZip(zipReader io.Reader, toolsDir string) error {
 			}
 		}
 
-		log.Printf("Extracted %s to %s", file.Name, outPath)
+		slog.Debug("extracted file", slog.String("name", file.Name), slog.String("path", outPath))
 	}
 
 	return nil // Successfully extracted all files
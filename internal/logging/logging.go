@@ -0,0 +1,73 @@
+// logging/logging.go
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Options configures the process-wide default logger. Every package in
+// this module logs via the slog package-level functions (slog.Info,
+// slog.Debug, ...), which always resolve to whatever slog.SetDefault was
+// last called with, so Configure only needs to run once, early in main.
+type Options struct {
+	// Format is "text" or "json". Defaults to "text".
+	Format string
+	// Level is "debug", "info", "warn", or "error". Defaults to "info".
+	Level string
+	// FilePath, if set, appends log output to this file instead of stderr.
+	FilePath string
+}
+
+// Configure builds a handler from opts, installs it as the slog default,
+// and returns a close func that must be called (typically via defer) to
+// flush and close the log file, if one was opened.
+func Configure(opts Options) (close func() error, err error) {
+	level, err := parseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var w *os.File = os.Stderr
+	closeFn := func() error { return nil }
+	if opts.FilePath != "" {
+		f, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("logging: failed to open log file %s: %w", opts.FilePath, err)
+		}
+		w = f
+		closeFn = f.Close
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch opts.Format {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		closeFn()
+		return nil, fmt.Errorf("logging: unknown log format %q (want \"text\" or \"json\")", opts.Format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return closeFn, nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	switch level {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("logging: unknown log level %q (want debug, info, warn, or error)", level)
+	}
+}
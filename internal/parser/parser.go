@@ -2,45 +2,39 @@
 package parser
 
 import (
-	"bufio"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
+
+	"github.com/SkinDelta/go-cs2-cdn/internal/cmdrunner"
+	"github.com/SkinDelta/go-cs2-cdn/internal/vpk"
 )
 
-// GenerateVPKList orchestrates the steps to generate the VPK list
+// GenerateVPKList opens the VPK directory at vpkDir, finds every entry
+// whose path starts with requiredPrefix, and writes the archive files
+// (pak01_NNN.vpk) that hold them under vpkBaseDir to outFile, one per
+// line. This list is what gets handed to DepotDownloader's -filelist so
+// only the archives actually containing the requested assets are fetched.
 func GenerateVPKList(vpkDir string, requiredPrefix string, vpkBaseDir string, outFile string) error {
-	// Step 1: Generate the manifest
-	manifestPath, err := generateVPKDirectory(vpkDir)
-	if err != nil {
-		return fmt.Errorf("error generating manifest: %w", err)
-	}
-	defer os.Remove(manifestPath) // Clean up the temporary manifest file
-
-	// Step 2: Parse the manifest to get fnumbers for required images
-	fnumbers, err := ParseVPKDir(manifestPath, requiredPrefix)
+	dir, err := vpk.Open(vpkDir)
 	if err != nil {
-		return fmt.Errorf("error parsing manifest: %w", err)
+		return fmt.Errorf("error opening VPK directory: %w", err)
 	}
 
-	if len(fnumbers) == 0 {
-		log.Println("No matching image files found in the manifest.")
+	entries := dir.Filter(requiredPrefix)
+	if len(entries) == 0 {
+		slog.Info("no matching image files found in the VPK directory", slog.String("prefix", requiredPrefix))
 		return nil
 	}
 
-	// Step 3: Map fnumbers to VPK filenames
-	vpks := MapFNumberToVPK(fnumbers, vpkBaseDir)
-
+	vpks := archiveFiles(entries, vpkBaseDir)
 	if len(vpks) == 0 {
-		log.Println("No VPKs mapped from the extracted fnumbers.")
+		slog.Info("no VPKs mapped from the matched entries")
 		return nil
 	}
 
-	// Step 4: Write the VPK list to the output file
 	if err := WriteVPKList(vpks, outFile); err != nil {
 		return fmt.Errorf("error writing VPK list: %w", err)
 	}
@@ -48,146 +42,107 @@ func GenerateVPKList(vpkDir string, requiredPrefix string, vpkBaseDir string, ou
 	return nil
 }
 
-// generateVPKDirectory runs the Source2Viewer-CLI command to generate the VPK Dir output file
-func generateVPKDirectory(vpkDir string) (string, error) {
-
-	// Create a temporary file to store the manifest
-	tempFile, err := os.CreateTemp("", "vpkdir_*.txt")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temporary manifest file: %w", err)
+// archiveFiles returns the sorted, de-duplicated set of pak01_NNN.vpk
+// paths (under baseDir) that hold entries, derived directly from each
+// entry's ArchiveIndex rather than a guessed/parsed fnumber.
+func archiveFiles(entries []vpk.Entry, baseDir string) []string {
+	vpkSet := make(map[string]struct{})
+	for _, e := range entries {
+		vpkName := fmt.Sprintf("pak01_%03d.vpk", e.ArchiveIndex)
+		vpkSet[filepath.Join(baseDir, vpkName)] = struct{}{}
 	}
-	defer tempFile.Close()
-
-	// Prepare and execute the command with the correct output path
-	log.Printf("Running Source2Viewer-CLI to generate vpk dir at %s", tempFile.Name())
-
-	cmd := exec.Command("tools/Source2Viewer-CLI",
-		"-i", vpkDir,
-		"--vpk_dir",
-	)
-
-	cmd.Stdout = tempFile
-	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("error running Source2Viewer-CLI: %w", err)
+	var vpks []string
+	for path := range vpkSet {
+		vpks = append(vpks, path)
 	}
-
-	log.Printf("vpk dir generated successfully at %s", tempFile.Name())
-	return tempFile.Name(), nil
+	return vpks
 }
 
-// ParseVPKDir parses the VPK dir file and returns a slice of unique fnumber values for required images
-func ParseVPKDir(vpkDirPath string, requiredPrefix string) ([]int, error) {
-	file, err := os.Open(vpkDirPath)
+// WriteVPKList writes the list of VPK paths to the output file
+func WriteVPKList(vpks []string, outFile string) error {
+	// Open the output file for writing
+	f, err := os.Create(outFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open VPK Dir file: %w", err)
+		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	fnumberSet := make(map[int]struct{})
-	matchedLines := 0
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip header and summary lines
-		if strings.HasPrefix(line, "---") ||
-			strings.TrimSpace(line) == "" {
-			continue
-		}
+	defer f.Close()
 
-		// Split the line into fields
-		fields := strings.Fields(line)
-		if len(fields) < 5 {
-			// Not enough fields to parse
-			continue
+	// Write each VPK filename to the file
+	for _, vpkPath := range vpks {
+		if _, err := f.WriteString(vpkPath + "\n"); err != nil {
+			return fmt.Errorf("failed to write to output file: %w", err)
 		}
+	}
 
-		// Extract FilePath (first field)
-		filePath := fields[0]
-
-		// Normalize the file path for consistent comparison
-		cleanPath := filepath.ToSlash(filepath.Clean(filePath))
-		normalizedPrefix := filepath.ToSlash(filepath.Clean(requiredPrefix))
+	return nil
+}
 
-		// Check if the FilePath starts with the required prefix
-		if !strings.HasPrefix(cleanPath, normalizedPrefix) {
-			continue
-		}
-		matchedLines++
-
-		// Extract fnumber from the fields
-		fnumber := 0
-		for _, field := range fields[1:] {
-			if strings.HasPrefix(field, "fnumber=") {
-				fnumStr := strings.TrimPrefix(field, "fnumber=")
-				fnum, err := strconv.Atoi(fnumStr)
-				if err != nil {
-					log.Printf("Warning: Invalid fnumber '%s' in line: %s", fnumStr, line)
-					break
-				}
-				fnumber = fnum
-				break
-			}
-		}
+// ExtractImages opens the VPK directory at vpkDir (whose archive files
+// are expected to already be present alongside it on disk, e.g. after
+// DepotDownloader has fetched them), stages every entry whose path starts
+// with requiredPrefix as a raw ".vtex_c" resource, and decompiles that
+// staging directory to real PNG pixel data in outDir.
+//
+// The native vpk package only reads the archives' directory tree and raw
+// entry bytes; it does not decode the game's compiled-texture format, so
+// Source2Viewer-CLI is still shelled out to for that last step, the same
+// way this tool always has.
+func ExtractImages(vpkDir string, requiredPrefix string, outDir string) error {
+	dir, err := vpk.Open(vpkDir)
+	if err != nil {
+		return fmt.Errorf("error opening VPK directory: %w", err)
+	}
 
-		if fnumber != 0 {
-			fnumberSet[fnumber] = struct{}{}
-		} else {
-			log.Printf("No valid fnumber found in line: %s", line)
-		}
+	entries := dir.Filter(requiredPrefix)
+	if len(entries) == 0 {
+		slog.Info("no matching image files found to extract", slog.String("prefix", requiredPrefix))
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading VPK Dir file: %w", err)
+	stagingDir, err := os.MkdirTemp("", "vtex-staging-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
 	}
+	defer os.RemoveAll(stagingDir)
 
-	log.Printf("Total matched lines: %d", matchedLines)
+	for _, entry := range entries {
+		if err := extractEntry(entry, stagingDir); err != nil {
+			return fmt.Errorf("error staging %s: %w", entry.Path, err)
+		}
+	}
 
-	// Convert the set to a slice
-	var fnumbers []int
-	for fnum := range fnumberSet {
-		fnumbers = append(fnumbers, fnum)
+	if err := cmdrunner.PipeOutput("tools/Source2Viewer-CLI", "-i", stagingDir, "-o", outDir, "-d"); err != nil {
+		return fmt.Errorf("error decompiling staged resources: %w", err)
 	}
 
-	return fnumbers, nil
+	slog.Info("extracted files", slog.Int("count", len(entries)), slog.String("dir", outDir))
+	return nil
 }
 
-// MapFNumberToVPK maps fnumber to VPK filenames
-func MapFNumberToVPK(fnumbers []int, baseDir string) []string {
-	vpkSet := make(map[string]struct{})
-	for _, fnum := range fnumbers {
-		// Adjust the formatting if VPK filenames have leading zeros
-		vpkName := fmt.Sprintf("pak01_%03d.vpk", fnum) // e.g., pak01_044.vpk or pak01_187.vpk
-		vpkPath := filepath.Join(baseDir, vpkName)
-		vpkSet[vpkPath] = struct{}{}
+// extractEntry writes a single VPK entry's raw, still-compiled contents
+// to stagingDir/entry.Path, unmodified, for Source2Viewer-CLI to
+// decompile afterwards.
+func extractEntry(entry vpk.Entry, stagingDir string) error {
+	r, err := entry.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to open entry reader: %w", err)
 	}
+	defer r.Close()
 
-	// Convert the set to a slice
-	var vpks []string
-	for vpk := range vpkSet {
-		vpks = append(vpks, vpk)
+	destPath := filepath.Join(stagingDir, filepath.FromSlash(entry.Path))
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", destPath, err)
 	}
 
-	return vpks
-}
-
-// WriteVPKList writes the list of VPK paths to the output file
-func WriteVPKList(vpks []string, outFile string) error {
-	// Open the output file for writing
-	f, err := os.Create(outFile)
+	f, err := os.Create(destPath)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return fmt.Errorf("failed to create %s: %w", destPath, err)
 	}
 	defer f.Close()
 
-	// Write each VPK filename to the file
-	for _, vpk := range vpks {
-		if _, err := f.WriteString(vpk + "\n"); err != nil {
-			return fmt.Errorf("failed to write to output file: %w", err)
-		}
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", destPath, err)
 	}
 
 	return nil
@@ -0,0 +1,93 @@
+// plugin/manifest.go
+package plugin
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hook identifies a point in the extraction flow where plugins can run.
+type Hook string
+
+const (
+	// HookPostExtract runs after images have been extracted from the VPK,
+	// before any CDN-specific renaming or publishing happens.
+	HookPostExtract Hook = "post-extract"
+	// HookPrePublish runs immediately before extracted files are walked
+	// and uploaded to the configured storage.Backend.
+	HookPrePublish Hook = "pre-publish"
+	// HookPostPublish runs after cdn.json has been written.
+	HookPostPublish Hook = "post-publish"
+)
+
+// ManifestFileName is the name every plugin must ship at its root.
+const ManifestFileName = "plugin.yaml"
+
+// Manifest describes a single plugin, as declared in its plugin.yaml.
+type Manifest struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Hooks   []Hook `yaml:"hooks"`
+	// Command is the executable (and any fixed arguments) to run for
+	// this plugin, resolved relative to the plugin's own directory.
+	Command string `yaml:"command"`
+
+	// dir is the plugin's directory, set by Discover.
+	dir string
+}
+
+// Matches reports whether the plugin declared interest in hook.
+func (m Manifest) Matches(hook Hook) bool {
+	for _, h := range m.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// Discover reads every plugins/*/plugin.yaml under dir and returns the
+// parsed manifests. A plugin directory without a plugin.yaml is skipped,
+// not an error, so users can drop unrelated files under plugins/.
+func Discover(dir string) ([]Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("plugin: failed to read plugins directory %s: %w", dir, err)
+	}
+
+	var manifests []Manifest
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pluginDir := filepath.Join(dir, entry.Name())
+		manifestPath := filepath.Join(pluginDir, ManifestFileName)
+
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("plugin: failed to read %s: %w", manifestPath, err)
+		}
+
+		var m Manifest
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("plugin: failed to parse %s: %w", manifestPath, err)
+		}
+		m.dir = pluginDir
+
+		slog.Debug("discovered plugin", slog.String("name", m.Name), slog.String("version", m.Version))
+		manifests = append(manifests, m)
+	}
+
+	return manifests, nil
+}
@@ -0,0 +1,109 @@
+// plugin/runner.go
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/SkinDelta/go-cs2-cdn/internal/cmdrunner"
+)
+
+// Request is the JSON document piped to a plugin's stdin at a hook point.
+type Request struct {
+	// Root is the extraction root (e.g. "static") the plugin should act on.
+	Root string `json:"root"`
+	// ManifestID is the Steam manifest ID for the current run.
+	ManifestID string `json:"manifest_id"`
+	// Files lists every file changed by the step that triggered the hook,
+	// relative to Root.
+	Files []string `json:"files"`
+}
+
+// Rename describes a file move a plugin wants applied, both paths
+// relative to the Request's Root.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Response is the JSON document a plugin writes to stdout describing how
+// the extraction root should change.
+type Response struct {
+	Renames   []Rename `json:"renames,omitempty"`
+	Deletions []string `json:"deletions,omitempty"`
+	// CDNEntries maps a Root-relative path to the URL it should be
+	// recorded under in cdn.json, for plugins that publish files
+	// themselves (e.g. to their own backend) instead of letting the
+	// normal publish step handle it.
+	CDNEntries map[string]string `json:"cdn_entries,omitempty"`
+}
+
+// Timeout bounds how long a single plugin invocation may run.
+const Timeout = 2 * time.Minute
+
+// Invoke runs every plugin in manifests that declared interest in hook,
+// passing req as a JSON document on stdin, and returns their parsed
+// responses. A plugin that exits non-zero or emits unparseable output
+// fails the whole Invoke call, since a partially-applied hook would leave
+// the extraction root in an inconsistent state.
+func Invoke(ctx context.Context, manifests []Manifest, hook Hook, req Request) ([]Response, error) {
+	var responses []Response
+
+	for _, m := range manifests {
+		if !m.Matches(hook) {
+			continue
+		}
+
+		resp, err := invokeOne(ctx, m, req)
+		if err != nil {
+			return nil, fmt.Errorf("plugin: %s failed on hook %s: %w", m.Name, hook, err)
+		}
+
+		slog.Info("plugin hook ran",
+			slog.String("plugin", m.Name),
+			slog.String("hook", string(hook)),
+			slog.Int("renames", len(resp.Renames)),
+			slog.Int("deletions", len(resp.Deletions)),
+		)
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+func invokeOne(ctx context.Context, m Manifest, req Request) (Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	fields := strings.Fields(m.Command)
+	if len(fields) == 0 {
+		return Response{}, fmt.Errorf("plugin %s has an empty command", m.Name)
+	}
+
+	result := cmdrunner.RunWithOptions(ctx, fields[0], fields[1:], cmdrunner.RunOptions{
+		Dir:   m.dir,
+		Stdin: bytes.NewReader(payload),
+	})
+	if result.Error != nil {
+		return Response{}, fmt.Errorf("%w (stderr: %s)", result.Error, result.Stderr)
+	}
+
+	var resp Response
+	if strings.TrimSpace(result.Stdout) == "" {
+		return resp, nil
+	}
+	if err := json.Unmarshal([]byte(result.Stdout), &resp); err != nil {
+		return Response{}, fmt.Errorf("failed to parse plugin response: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,63 @@
+// storage/config.go
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes which backend to publish extracted assets to. It is
+// loaded from a YAML file (--storage-config) with environment variable
+// overrides, so CI can inject credentials without committing them.
+type Config struct {
+	// URL is the backend URL, e.g. "s3://bucket/prefix?region=us-east-1".
+	// Falls back to the CDN_STORAGE_URL environment variable, and finally
+	// to a local "static" mirror with the historical jsDelivr URL.
+	URL string `yaml:"url"`
+}
+
+const (
+	envStorageURL = "CDN_STORAGE_URL"
+
+	// defaultBackendURL reproduces the tool's historical behavior: write
+	// PNGs under static/ (relative to the working directory, since keys
+	// already carry the "static/" prefix) and serve them from the
+	// SkinDelta jsDelivr mirror, which serves a file at static/foo.png in
+	// the repo at "https://cdn.jsdelivr.net/gh/SkinDelta/go-cs2-cdn@main/static/foo.png".
+	defaultBackendURL = "file://.?base_url=" +
+		"https://cdn.jsdelivr.net/gh/SkinDelta/go-cs2-cdn@main"
+)
+
+// LoadConfig reads a storage Config from path, a YAML file. If path is
+// empty or does not exist, a zero-value Config is returned (not an error)
+// so that environment variables or defaults can still apply.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("storage: failed to read config %s: %w", path, err)
+			}
+		} else if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("storage: failed to parse config %s: %w", path, err)
+		}
+	}
+
+	if env := os.Getenv(envStorageURL); env != "" {
+		cfg.URL = env
+	}
+	if cfg.URL == "" {
+		cfg.URL = defaultBackendURL
+	}
+
+	return cfg, nil
+}
+
+// NewFromConfig constructs the Backend described by cfg.
+func NewFromConfig(cfg *Config) (Backend, error) {
+	return New(cfg.URL)
+}
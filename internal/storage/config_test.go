@@ -0,0 +1,37 @@
+// storage/config_test.go
+package storage
+
+import "testing"
+
+// TestDefaultConfigRoundTrip exercises LoadConfig("") -> NewFromConfig ->
+// URL(key) end to end, pinning the historical flat static/ layout: a key
+// already prefixed with "static/" must resolve to a local path of
+// "static/..." (not "static/static/...") and a public URL under the
+// jsDelivr mirror with no doubled "static" segment either.
+func TestDefaultConfigRoundTrip(t *testing.T) {
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	backend, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+
+	local, ok := backend.(*LocalBackend)
+	if !ok {
+		t.Fatalf("NewFromConfig returned %T, want *LocalBackend", backend)
+	}
+
+	const key = "static/weapons/ak47.png"
+
+	if got, want := local.path(key), "static/weapons/ak47.png"; got != want {
+		t.Errorf("path(%q) = %q, want %q", key, got, want)
+	}
+
+	const wantURL = "https://cdn.jsdelivr.net/gh/SkinDelta/go-cs2-cdn@main/static/weapons/ak47.png"
+	if got := local.URL(key); got != wantURL {
+		t.Errorf("URL(%q) = %q, want %q", key, got, wantURL)
+	}
+}
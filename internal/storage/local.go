@@ -0,0 +1,125 @@
+// storage/local.go
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register("file", newLocalBackendFromURL)
+	Register("", newLocalBackendFromURL) // bare filesystem paths
+}
+
+// LocalBackend publishes files to a directory on the local filesystem,
+// mirroring the "just write cdn.json next to the PNGs" behavior this tool
+// had before pluggable backends existed.
+type LocalBackend struct {
+	// Root is the directory files are written under.
+	Root string
+	// BaseURL, if set, is prepended to a key to form the public URL.
+	// Defaults to a "file://" URL pointing at Root.
+	BaseURL string
+}
+
+// NewLocalBackend returns a Backend rooted at dir.
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{Root: dir}
+}
+
+func newLocalBackendFromURL(u *url.URL) (Backend, error) {
+	dir := u.Opaque
+	if dir == "" {
+		// url.Parse puts a "file://host/path"-style URL's first path
+		// segment into Host, not Path (e.g. "file://static?..." parses to
+		// Host="static", Path=""), so both must be combined to recover the
+		// intended directory.
+		dir = filepath.Join(u.Host, u.Path)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("local backend URL %q has no path", u.String())
+	}
+
+	b := NewLocalBackend(dir)
+	if base := u.Query().Get("base_url"); base != "" {
+		b.BaseURL = base
+	}
+	return b, nil
+}
+
+func (b *LocalBackend) path(key string) string {
+	return filepath.Join(b.Root, filepath.FromSlash(key))
+}
+
+// PutFile writes r to Root/key, creating any necessary directories.
+func (b *LocalBackend) PutFile(ctx context.Context, key string, r io.Reader, meta Metadata) error {
+	dest := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("local: failed to create directory for %s: %w", key, err)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("local: failed to create %s: %w", dest, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("local: failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// Delete removes Root/key. A missing file is not an error.
+func (b *LocalBackend) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(b.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("local: failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns BaseURL+key if BaseURL is set, otherwise a file:// URL.
+func (b *LocalBackend) URL(key string) string {
+	if b.BaseURL != "" {
+		return strings.TrimRight(b.BaseURL, "/") + "/" + key
+	}
+	return "file://" + filepath.ToSlash(b.path(key))
+}
+
+// List walks Root looking for entries whose key starts with prefix.
+func (b *LocalBackend) List(ctx context.Context, prefix string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(b.Root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.Root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local: failed to list %q: %w", prefix, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,62 @@
+// storage/local_test.go
+package storage
+
+import "testing"
+
+func TestNewLocalBackendFromURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		wantRoot string
+		wantBase string
+	}{
+		{
+			name:     "bare path",
+			url:      "/var/www/cdn",
+			wantRoot: "/var/www/cdn",
+		},
+		{
+			name:     "file scheme with host-as-path",
+			url:      "file://static?base_url=https://cdn.example.com/static",
+			wantRoot: "static",
+			wantBase: "https://cdn.example.com/static",
+		},
+		{
+			name:     "file scheme with nested path",
+			url:      "file://static/nested?base_url=https://cdn.example.com",
+			wantRoot: "static/nested",
+			wantBase: "https://cdn.example.com",
+		},
+		{
+			name:     "current directory",
+			url:      "file://.?base_url=https://cdn.example.com",
+			wantRoot: ".",
+			wantBase: "https://cdn.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := New(tt.url)
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.url, err)
+			}
+			local, ok := backend.(*LocalBackend)
+			if !ok {
+				t.Fatalf("New(%q) returned %T, want *LocalBackend", tt.url, backend)
+			}
+			if local.Root != tt.wantRoot {
+				t.Errorf("Root = %q, want %q", local.Root, tt.wantRoot)
+			}
+			if local.BaseURL != tt.wantBase {
+				t.Errorf("BaseURL = %q, want %q", local.BaseURL, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestNewLocalBackendFromURLNoPath(t *testing.T) {
+	if _, err := New("file://"); err == nil {
+		t.Error("New(\"file://\") = nil error, want error for missing path")
+	}
+}
@@ -0,0 +1,141 @@
+// storage/s3.go
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func init() {
+	Register("s3", newS3BackendFromURL)
+}
+
+// S3Backend publishes files to an S3 (or S3-compatible) bucket.
+type S3Backend struct {
+	Bucket   string
+	Prefix   string
+	BaseURL  string
+	client   *s3.Client
+	region   string
+	endpoint string
+}
+
+// NewS3Backend returns a Backend that writes to bucket under prefix using client.
+func NewS3Backend(client *s3.Client, bucket, prefix string) *S3Backend {
+	return &S3Backend{Bucket: bucket, Prefix: strings.Trim(prefix, "/"), client: client}
+}
+
+// newS3BackendFromURL builds an S3Backend from a URL of the form
+// s3://bucket/prefix?region=us-east-1&endpoint=https://...&base_url=https://cdn.example.com
+func newS3BackendFromURL(u *url.URL) (Backend, error) {
+	bucket := u.Host
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 backend URL %q is missing a bucket name", u.String())
+	}
+
+	q := u.Query()
+	region := q.Get("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("s3: failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := q.Get("endpoint"); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	b := NewS3Backend(client, bucket, u.Path)
+	b.region = region
+	b.endpoint = q.Get("endpoint")
+	b.BaseURL = q.Get("base_url")
+	return b, nil
+}
+
+func (b *S3Backend) objectKey(key string) string {
+	if b.Prefix == "" {
+		return key
+	}
+	return b.Prefix + "/" + key
+}
+
+// PutFile uploads r to the S3 object Prefix/key.
+func (b *S3Backend) PutFile(ctx context.Context, key string, r io.Reader, meta Metadata) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+		Body:   r,
+	}
+	if meta.ContentType != "" {
+		input.ContentType = aws.String(meta.ContentType)
+	}
+
+	if _, err := b.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("s3: failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the S3 object Prefix/key.
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("s3: failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns BaseURL+key if set, otherwise the default virtual-hosted-style
+// S3 URL for the object.
+func (b *S3Backend) URL(key string) string {
+	if b.BaseURL != "" {
+		return strings.TrimRight(b.BaseURL, "/") + "/" + key
+	}
+	if b.endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimRight(b.endpoint, "/"), b.Bucket, b.objectKey(key))
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", b.Bucket, b.region, b.objectKey(key))
+}
+
+// List returns every object under Prefix/prefix, paginating as needed.
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]Entry, error) {
+	var entries []Entry
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.Bucket),
+		Prefix: aws.String(b.objectKey(prefix)),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("s3: failed to list %q: %w", prefix, err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if b.Prefix != "" {
+				key = strings.TrimPrefix(key, b.Prefix+"/")
+			}
+			entries = append(entries, Entry{
+				Key:     key,
+				Size:    aws.ToInt64(obj.Size),
+				ModTime: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+// vpk/reader.go
+package vpk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader returns the entry's file contents: its preload bytes followed by
+// the remainder of the data, transparently opening the numbered
+// pak01_NNN.vpk archive (or seeking within the _dir.vpk itself, for
+// version-1 archives that inline small files) as needed. The caller must
+// Close the returned ReadCloser.
+func (e *Entry) Reader() (io.ReadCloser, error) {
+	if e.EntryLength == 0 {
+		return io.NopCloser(bytes.NewReader(e.PreloadBytes)), nil
+	}
+
+	var (
+		archivePath string
+		offset      int64
+	)
+	if e.ArchiveIndex == archiveIndexInDir {
+		archivePath = e.dir.Path
+		offset = e.dir.treeDataEnd + int64(e.EntryOffset)
+	} else {
+		archivePath = e.archivePath()
+		offset = int64(e.EntryOffset)
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("vpk: failed to open archive %s for %s: %w", archivePath, e.Path, err)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("vpk: failed to seek in %s for %s: %w", archivePath, e.Path, err)
+	}
+
+	body := io.NewSectionReader(f, offset, int64(e.EntryLength))
+
+	if len(e.PreloadBytes) == 0 {
+		return &fileSectionReadCloser{Reader: body, file: f}, nil
+	}
+
+	return &fileSectionReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(e.PreloadBytes), body),
+		file:   f,
+	}, nil
+}
+
+// fileSectionReadCloser wraps a Reader over a still-open *os.File so the
+// file descriptor is released when the caller is done reading.
+type fileSectionReadCloser struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *fileSectionReadCloser) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,236 @@
+// vpk/vpk.go
+package vpk
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// signature is the magic number at the start of every Valve VPK directory file.
+const signature uint32 = 0x55aa1234
+
+// terminator marks the end of each directory tree entry's fixed-size fields.
+const terminator uint16 = 0xffff
+
+// archiveIndexInDir is the sentinel ArchiveIndex meaning an entry's bytes
+// live in the _dir.vpk itself (after the directory tree) rather than in a
+// numbered pak01_NNN.vpk archive.
+const archiveIndexInDir uint16 = 0x7fff
+
+// Entry describes a single file packed into a VPK archive set.
+type Entry struct {
+	// Path is the entry's full path, e.g. "panorama/images/econ/weapons/ak47_png.vtex_c".
+	Path string
+
+	CRC          uint32
+	PreloadBytes []byte
+	ArchiveIndex uint16
+	EntryOffset  uint32
+	EntryLength  uint32
+
+	dir *Dir
+}
+
+// Dir is the parsed directory tree of a Valve VPK v1/v2 archive set.
+type Dir struct {
+	// Path is the path to the _dir.vpk file this Dir was opened from.
+	Path string
+	// Version is 1 or 2, as read from the header.
+	Version uint32
+
+	Entries []Entry
+
+	// treeDataEnd is the offset in Path immediately following the
+	// directory tree, where version-1 archives store entries whose
+	// ArchiveIndex is archiveIndexInDir.
+	treeDataEnd int64
+}
+
+// Open parses the VPK directory file at path (conventionally named
+// "..._dir.vpk") and returns its entry tree. It does not open any of the
+// numbered archive files; that happens lazily in Entry.Reader.
+func Open(path string) (*Dir, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("vpk: failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	var sig, version, treeSize uint32
+	if err := binary.Read(r, binary.LittleEndian, &sig); err != nil {
+		return nil, fmt.Errorf("vpk: failed to read signature: %w", err)
+	}
+	if sig != signature {
+		return nil, fmt.Errorf("vpk: %s is not a VPK file (bad signature 0x%x)", path, sig)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("vpk: failed to read version: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &treeSize); err != nil {
+		return nil, fmt.Errorf("vpk: failed to read tree size: %w", err)
+	}
+
+	headerSize := int64(12) // signature + version + treeSize
+	if version == 2 {
+		var fileDataSize, archiveMD5Size, otherMD5Size, sigSectionSize uint32
+		for _, field := range []*uint32{&fileDataSize, &archiveMD5Size, &otherMD5Size, &sigSectionSize} {
+			if err := binary.Read(r, binary.LittleEndian, field); err != nil {
+				return nil, fmt.Errorf("vpk: failed to read v2 header: %w", err)
+			}
+		}
+		headerSize += 16
+	} else if version != 1 {
+		return nil, fmt.Errorf("vpk: unsupported VPK version %d", version)
+	}
+
+	entries, err := readTree(r)
+	if err != nil {
+		return nil, fmt.Errorf("vpk: failed to read directory tree of %s: %w", path, err)
+	}
+
+	d := &Dir{
+		Path:        path,
+		Version:     version,
+		treeDataEnd: headerSize + int64(treeSize),
+	}
+	for i := range entries {
+		entries[i].dir = d
+	}
+	d.Entries = entries
+
+	return d, nil
+}
+
+// readTree reads the extension/path/filename tree, terminated by three
+// nested empty strings, returning one Entry per file.
+func readTree(r *bufio.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	for {
+		ext, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		if ext == "" {
+			break
+		}
+
+		for {
+			dirPath, err := readCString(r)
+			if err != nil {
+				return nil, err
+			}
+			if dirPath == "" {
+				break
+			}
+			if dirPath == " " {
+				dirPath = "" // VPK's convention for "root directory"
+			}
+
+			for {
+				name, err := readCString(r)
+				if err != nil {
+					return nil, err
+				}
+				if name == "" {
+					break
+				}
+
+				entry, err := readDirEntry(r)
+				if err != nil {
+					return nil, err
+				}
+
+				parts := []string{}
+				if dirPath != "" {
+					parts = append(parts, dirPath)
+				}
+				parts = append(parts, name+"."+ext)
+				entry.Path = strings.Join(parts, "/")
+
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// readDirEntry reads a single entry's fixed fields, terminator, and any
+// preload bytes that immediately follow it in the tree.
+func readDirEntry(r *bufio.Reader) (Entry, error) {
+	var e Entry
+
+	if err := binary.Read(r, binary.LittleEndian, &e.CRC); err != nil {
+		return e, fmt.Errorf("failed to read CRC: %w", err)
+	}
+
+	var preloadBytes uint16
+	if err := binary.Read(r, binary.LittleEndian, &preloadBytes); err != nil {
+		return e, fmt.Errorf("failed to read preload bytes count: %w", err)
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &e.ArchiveIndex); err != nil {
+		return e, fmt.Errorf("failed to read archive index: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &e.EntryOffset); err != nil {
+		return e, fmt.Errorf("failed to read entry offset: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &e.EntryLength); err != nil {
+		return e, fmt.Errorf("failed to read entry length: %w", err)
+	}
+
+	var term uint16
+	if err := binary.Read(r, binary.LittleEndian, &term); err != nil {
+		return e, fmt.Errorf("failed to read terminator: %w", err)
+	}
+	if term != terminator {
+		return e, fmt.Errorf("unexpected terminator 0x%x", term)
+	}
+
+	if preloadBytes > 0 {
+		e.PreloadBytes = make([]byte, preloadBytes)
+		if _, err := io.ReadFull(r, e.PreloadBytes); err != nil {
+			return e, fmt.Errorf("failed to read %d preload bytes: %w", preloadBytes, err)
+		}
+	}
+
+	return e, nil
+}
+
+// readCString reads bytes up to and including a NUL terminator and
+// returns them as a string with the terminator stripped.
+func readCString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read null-terminated string: %w", err)
+	}
+	return strings.TrimSuffix(s, "\x00"), nil
+}
+
+// Filter returns every Entry whose Path starts with prefix.
+func (d *Dir) Filter(prefix string) []Entry {
+	var matches []Entry
+	for _, e := range d.Entries {
+		if strings.HasPrefix(e.Path, prefix) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// archivePath returns the path to the numbered archive file
+// (e.g. "pak01_003.vpk") holding e's non-preload bytes, resolved relative
+// to its Dir's directory.
+func (e *Entry) archivePath() string {
+	base := strings.TrimSuffix(filepath.Base(e.dir.Path), "_dir.vpk")
+	name := fmt.Sprintf("%s_%03d.vpk", base, e.ArchiveIndex)
+	return filepath.Join(filepath.Dir(e.dir.Path), name)
+}
@@ -0,0 +1,101 @@
+// vpk/vpk_test.go
+package vpk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestVPK builds a minimal, valid VPK v1 directory file containing a
+// single entry at root/name.ext whose body is entirely preload bytes (so
+// Reader doesn't need a companion pak01_NNN.vpk archive on disk), and
+// returns its path.
+func writeTestVPK(t *testing.T, ext, name string, preload []byte) string {
+	t.Helper()
+
+	var tree bytes.Buffer
+	tree.WriteString(ext)
+	tree.WriteByte(0)
+	tree.WriteString(" ") // root directory sentinel
+	tree.WriteByte(0)
+	tree.WriteString(name)
+	tree.WriteByte(0)
+
+	binary.Write(&tree, binary.LittleEndian, uint32(0))            // CRC
+	binary.Write(&tree, binary.LittleEndian, uint16(len(preload))) // preload count
+	binary.Write(&tree, binary.LittleEndian, uint16(0))            // ArchiveIndex
+	binary.Write(&tree, binary.LittleEndian, uint32(0))            // EntryOffset
+	binary.Write(&tree, binary.LittleEndian, uint32(0))            // EntryLength
+	binary.Write(&tree, binary.LittleEndian, terminator)
+	tree.Write(preload)
+
+	tree.WriteByte(0) // end filenames
+	tree.WriteByte(0) // end directories
+	tree.WriteByte(0) // end extensions
+
+	var file bytes.Buffer
+	binary.Write(&file, binary.LittleEndian, signature)
+	binary.Write(&file, binary.LittleEndian, uint32(1)) // version 1
+	binary.Write(&file, binary.LittleEndian, uint32(tree.Len()))
+	file.Write(tree.Bytes())
+
+	path := filepath.Join(t.TempDir(), "test_dir.vpk")
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write test VPK: %v", err)
+	}
+	return path
+}
+
+func TestOpenAndFilter(t *testing.T) {
+	path := writeTestVPK(t, "vtex_c", "weapons/ak47_png", []byte("hello"))
+
+	dir, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if dir.Version != 1 {
+		t.Errorf("Version = %d, want 1", dir.Version)
+	}
+	if len(dir.Entries) != 1 {
+		t.Fatalf("len(Entries) = %d, want 1", len(dir.Entries))
+	}
+
+	const wantPath = "weapons/ak47_png.vtex_c"
+	if got := dir.Entries[0].Path; got != wantPath {
+		t.Errorf("Path = %q, want %q", got, wantPath)
+	}
+
+	if matches := dir.Filter("weapons/"); len(matches) != 1 {
+		t.Errorf("Filter(\"weapons/\") = %d entries, want 1", len(matches))
+	}
+	if matches := dir.Filter("models/"); len(matches) != 0 {
+		t.Errorf("Filter(\"models/\") = %d entries, want 0", len(matches))
+	}
+}
+
+func TestEntryReaderPreloadOnly(t *testing.T) {
+	path := writeTestVPK(t, "vtex_c", "ak47_png", []byte("hello"))
+
+	dir, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	r, err := dir.Entries[0].Reader()
+	if err != nil {
+		t.Fatalf("Reader: %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("data = %q, want %q", data, "hello")
+	}
+}
@@ -0,0 +1,78 @@
+// econ-rename is the built-in post-extract plugin that strips the "_png"
+// substring DepotDownloader's VPK entries carry in their file names (e.g.
+// "weapon_ak47_png.png" -> "weapon_ak47.png"). It reproduces the renaming
+// go-cs2-cdn performed inline before the plugin system existed.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// request mirrors internal/plugin.Request.
+type request struct {
+	Root       string   `json:"root"`
+	ManifestID string   `json:"manifest_id"`
+	Files      []string `json:"files"`
+}
+
+// rename mirrors internal/plugin.Rename.
+type rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// response mirrors internal/plugin.Response.
+type response struct {
+	Renames []rename `json:"renames,omitempty"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "econ-rename:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	var resp response
+	err := filepath.WalkDir(req.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !strings.HasSuffix(d.Name(), ".png") {
+			return nil
+		}
+
+		newName := strings.ReplaceAll(d.Name(), "_png", "")
+		if newName == d.Name() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(req.Root, path)
+		if err != nil {
+			return fmt.Errorf("failed to relativize %s: %w", path, err)
+		}
+		newRel := filepath.Join(filepath.Dir(rel), newName)
+
+		resp.Renames = append(resp.Renames, rename{
+			From: filepath.ToSlash(rel),
+			To:   filepath.ToSlash(newRel),
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", req.Root, err)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(resp)
+}